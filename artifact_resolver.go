@@ -8,8 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/v2/pkg/skaffold/docker"
 	"github.com/GoogleContainerTools/skaffold/v2/pkg/skaffold/schema/latest"
@@ -17,6 +19,37 @@ import (
 	"github.com/rjeczalik/notify"
 )
 
+// EventKind identifies what kind of artifact event occurred.
+type EventKind string
+
+const (
+	EventFileChange       EventKind = "file-change"
+	EventRebuildTriggered EventKind = "rebuild-triggered"
+
+	// EventBuildQueued through EventBuildCancelled mirror a RebuildJob's
+	// per-artifact JobState transitions (see rebuild_job.go), so a
+	// subscriber can follow a submitted job's progress over /events or /ws
+	// instead of only by polling GET /jobs/{id}.
+	EventBuildQueued    EventKind = "build-queued"
+	EventBuildRunning   EventKind = "build-running"
+	EventBuildSucceeded EventKind = "build-succeeded"
+	EventBuildFailed    EventKind = "build-failed"
+	EventBuildCancelled EventKind = "build-cancelled"
+)
+
+// Event describes a single artifact lifecycle occurrence, published to
+// subscribers registered via ArtifactResolver.Subscribe.
+type Event struct {
+	ArtifactName string    `json:"artifactName"`
+	Kind         EventKind `json:"kind"`
+	Path         string    `json:"path"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// subscriberChanSize bounds each subscriber's channel so a slow consumer
+// can't backpressure the watcher goroutine; events are dropped instead.
+const subscriberChanSize = 64
+
 type artifact struct {
 	imageName   string
 	workspace   string
@@ -27,12 +60,37 @@ type ArtifactResolver struct {
 	mtx       sync.Mutex
 	artifacts map[string]*artifact
 	watches   map[string]chan<- notify.EventInfo
+
+	subMtx      sync.Mutex
+	subscribers map[string]chan Event
+	nextSubID   int
+
+	driverMtx         sync.Mutex
+	driverInstances   map[string]TriggerDriver
+	defaultDriver     string
+	artifactDriver    map[string]string
+	artifactDriverKey map[string]string
+
+	cfgMtx              sync.RWMutex
+	config              *Config
+	autoRebuildOverride map[string]bool
+
+	jobsMtx    sync.Mutex
+	jobs       map[string]*RebuildJob
+	nextJobSeq int
 }
 
 func NewArtifactResolver() *ArtifactResolver {
 	return &ArtifactResolver{
-		artifacts: make(map[string]*artifact),
-		watches:   make(map[string]chan<- notify.EventInfo),
+		artifacts:           make(map[string]*artifact),
+		watches:             make(map[string]chan<- notify.EventInfo),
+		subscribers:         make(map[string]chan Event),
+		driverInstances:     make(map[string]TriggerDriver),
+		defaultDriver:       defaultDriverName,
+		artifactDriver:      make(map[string]string),
+		artifactDriverKey:   make(map[string]string),
+		autoRebuildOverride: make(map[string]bool),
+		jobs:                make(map[string]*RebuildJob),
 	}
 }
 
@@ -44,6 +102,46 @@ func (r *ArtifactResolver) AddWatch(path string, c chan<- notify.EventInfo, even
 	return nil
 }
 
+// Subscribe registers a new event listener and returns its id and a
+// receive-only channel of events. Call Unsubscribe with the returned id
+// once the listener is done to release the channel.
+func (r *ArtifactResolver) Subscribe() (string, <-chan Event) {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+
+	r.nextSubID++
+	id := strconv.Itoa(r.nextSubID)
+	ch := make(chan Event, subscriberChanSize)
+	r.subscribers[id] = ch
+	return id, ch
+}
+
+// Unsubscribe removes a subscriber registered via Subscribe and closes its
+// channel.
+func (r *ArtifactResolver) Unsubscribe(id string) {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+
+	if ch, ok := r.subscribers[id]; ok {
+		delete(r.subscribers, id)
+		close(ch)
+	}
+}
+
+// publish fans an event out to all current subscribers. Subscribers whose
+// channel is full are skipped rather than blocked.
+func (r *ArtifactResolver) publish(e Event) {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+
+	for _, ch := range r.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
 type fakeEventInfo struct {
 	path string
 }
@@ -68,8 +166,40 @@ func (f fakeEventInfo) Sys() interface{} {
 //   - "front*" would match artifacts with names starting with "front"
 //   - "*" would match all artifacts
 //
+// Artifacts with autoRebuild disabled via config are skipped when matched by
+// a wildcard pattern; a literal, non-wildcard pattern still rebuilds them
+// directly, matching the "unless /rebuild/<name> is hit" contract.
+//
+// changedPath is forwarded to each Rule.allowsPath check (see TriggerRebuild);
+// pass "" when the caller has no specific changed file to report, which
+// disables ignore/include filtering rather than falsely excluding artifacts.
+//
 // Returns an error if no artifacts match the pattern or if any rebuild fails.
-func (r *ArtifactResolver) TriggerRebuilds(pattern string) error {
+func (r *ArtifactResolver) TriggerRebuilds(ctx context.Context, pattern, changedPath string) error {
+	matchedArtifacts, err := r.resolvePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, artifactName := range matchedArtifacts {
+		if err := r.TriggerRebuild(ctx, artifactName, changedPath); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to trigger rebuild for %s: %v", artifactName, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors triggering rebuilds: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// resolvePattern expands a comma-separated list of glob patterns (as
+// accepted by TriggerRebuilds and SubmitRebuildJob) into the matching,
+// currently-known artifact names. Artifacts with autoRebuild disabled via
+// config are skipped when matched by a wildcard pattern; a literal,
+// non-wildcard pattern still matches them directly.
+func (r *ArtifactResolver) resolvePattern(pattern string) ([]string, error) {
 	artifacts := r.GetArtifacts()
 
 	patterns := strings.Split(pattern, ",")
@@ -81,32 +211,26 @@ func (r *ArtifactResolver) TriggerRebuilds(pattern string) error {
 	for _, p := range patterns {
 		gp, err := glob.Compile(p)
 		if err != nil {
-			return fmt.Errorf("invalid pattern %q: %w", p, err)
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
 		}
+		wildcard := strings.ContainsAny(p, "*?[{")
 		for _, artifactName := range artifacts {
-			if gp.Match(artifactName) {
-				matchedArtifacts = append(matchedArtifacts, artifactName)
+			if !gp.Match(artifactName) {
+				continue
+			}
+			if wildcard && !r.autoRebuildEnabled(artifactName) {
+				continue
 			}
+			matchedArtifacts = append(matchedArtifacts, artifactName)
 		}
 	}
 
 	fmt.Fprintf(os.Stderr, "\033[31mTriggering rebuilds for pattern: '%s', matched artifacts: %v\033[0m\n", pattern, matchedArtifacts)
 
 	if len(matchedArtifacts) == 0 {
-		return fmt.Errorf("no artifacts matched pattern: %s", pattern)
-	}
-
-	var errs []string
-	for _, artifactName := range matchedArtifacts {
-		if err := r.TriggerRebuild(artifactName); err != nil {
-			errs = append(errs, fmt.Sprintf("failed to trigger rebuild for %s: %v", artifactName, err))
-		}
+		return nil, fmt.Errorf("no artifacts matched pattern: %s", pattern)
 	}
-
-	if len(errs) > 0 {
-		return fmt.Errorf("errors triggering rebuilds: %s", strings.Join(errs, "; "))
-	}
-	return nil
+	return matchedArtifacts, nil
 }
 
 func (r *ArtifactResolver) WatchPath(workspace string) (string, error) {
@@ -126,23 +250,76 @@ func (r *ArtifactResolver) WatchPath(workspace string) (string, error) {
 	return watchPath, nil
 }
 
-func (r *ArtifactResolver) TriggerRebuild(artifactName string) error {
+// TriggerRebuild notifies Skaffold's watcher that the given artifact changed,
+// using whichever TriggerDriver is configured for it (the default driver
+// unless overridden by a matching config rule or via SetArtifactDriver).
+// changedPath, if non-empty, is
+// the real file the caller believes changed (e.g. supplied by a webhook or
+// editor plugin via /rebuild/<pattern>?path=...) and is checked against the
+// artifact's rule's Ignore/Include globs; art.triggerFile itself is a
+// synthetic per-artifact control file, never a real source path, so it must
+// never be passed here. An empty changedPath skips that filtering entirely,
+// since there's nothing concrete to match against.
+//
+// The EventRebuildTriggered event is published by NotifyWatch, not here: a
+// debouncing driver like inotify-batch can return from Trigger well before
+// it actually notifies the watcher, and publishing on that early return
+// would emit one event per call instead of one per coalesced rebuild.
+func (r *ArtifactResolver) TriggerRebuild(ctx context.Context, artifactName, changedPath string) error {
 	r.mtx.Lock()
-	defer r.mtx.Unlock()
-
 	art, ok := r.artifacts[artifactName]
+	driverName := r.driverNameForArtifact(artifactName)
+	driverKey := r.driverKeyForArtifact(artifactName)
+	r.mtx.Unlock()
+
 	if !ok {
 		return fmt.Errorf("artifact not found: %s", artifactName)
 	}
 
+	r.cfgMtx.RLock()
+	rule := r.config.ruleFor(artifactName)
+	r.cfgMtx.RUnlock()
+	if rule != nil && !rule.allowsPath(changedPath) {
+		return nil
+	}
+
+	driver, err := r.driverFor(driverKey, driverName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve trigger driver for %s: %w", artifactName, err)
+	}
+
+	// cause is reported to drivers that surface it downstream (e.g.
+	// webhookTriggerDriver's payload): the real changed path when the caller
+	// reported one, or "manual-rebuild" for a pattern-wide /rebuild hit with
+	// no specific file behind it.
+	cause := changedPath
+	if cause == "" {
+		cause = "manual-rebuild"
+	}
+
+	if err := driver.Trigger(ctx, art, cause); err != nil {
+		return fmt.Errorf("trigger driver %q failed for %s: %w", driver.Name(), artifactName, err)
+	}
+
+	return nil
+}
+
+// NotifyWatch writes a synthetic change to the artifact's trigger file and
+// pushes the resulting event onto its registered watch channel. It satisfies
+// WatchNotifier, the capability TriggerDriver implementations use to reach
+// Skaffold's watcher.
+func (r *ArtifactResolver) NotifyWatch(art *artifact) error {
+	r.mtx.Lock()
 	watchPath, err := r.WatchPath(art.workspace)
 	if err != nil {
+		r.mtx.Unlock()
 		return fmt.Errorf("failed to get watch path: %w", err)
 	}
-
 	watch, ok := r.watches[watchPath]
+	r.mtx.Unlock()
+
 	if !ok {
-		return fmt.Errorf("watch not found for artifact: %s (watch path: %s)", artifactName, watchPath)
+		return fmt.Errorf("watch not found for artifact: %s (watch path: %s)", art.imageName, watchPath)
 	}
 
 	// Write a random byte to trigger file to simulate a change
@@ -151,10 +328,218 @@ func (r *ArtifactResolver) TriggerRebuild(artifactName string) error {
 	}
 
 	watch <- &fakeEventInfo{path: art.triggerFile}
+	r.publish(Event{ArtifactName: art.imageName, Kind: EventFileChange, Path: art.triggerFile, Timestamp: time.Now()})
+	r.publish(Event{ArtifactName: art.imageName, Kind: EventRebuildTriggered, Path: art.triggerFile, Timestamp: time.Now()})
+
+	return nil
+}
+
+// driverNameForArtifact returns the registered driver type configured for the
+// given artifact, falling back to the resolver's default. Callers must hold
+// r.mtx.
+func (r *ArtifactResolver) driverNameForArtifact(artifactName string) string {
+	if name, ok := r.artifactDriver[artifactName]; ok {
+		return name
+	}
+	return r.defaultDriver
+}
+
+// driverKeyForArtifact returns the driverInstances cache key for the given
+// artifact: the rule-scoped key recorded by applyRuleLocked if one matched,
+// or the driver type name itself, shared by every artifact using it (the
+// default driver, or one picked via SetArtifactDriver, which carries no
+// rule-specific settings to keep separate). Callers must hold r.mtx.
+func (r *ArtifactResolver) driverKeyForArtifact(artifactName string) string {
+	if key, ok := r.artifactDriverKey[artifactName]; ok {
+		return key
+	}
+	return r.driverNameForArtifact(artifactName)
+}
+
+// driverFor returns the (lazily constructed, cached) TriggerDriver instance
+// for key, constructing it as a typeName driver if not already cached. key
+// distinguishes driver instances that share a type but carry different
+// per-rule settings (debounce, webhook URL); typeName alone would collapse
+// them into one shared instance and let the last-applied rule's settings
+// silently win for every artifact using that driver type.
+func (r *ArtifactResolver) driverFor(key, typeName string) (TriggerDriver, error) {
+	r.driverMtx.Lock()
+	defer r.driverMtx.Unlock()
+
+	if d, ok := r.driverInstances[key]; ok {
+		return d, nil
+	}
+
+	d, err := newTriggerDriver(typeName, r)
+	if err != nil {
+		return nil, err
+	}
+	r.driverInstances[key] = d
+	return d, nil
+}
+
+// SetDefaultDriver changes the trigger driver used for artifacts without a
+// per-artifact override.
+func (r *ArtifactResolver) SetDefaultDriver(name string) error {
+	if !isRegisteredTriggerDriver(name) {
+		return fmt.Errorf("unknown trigger driver: %s", name)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.defaultDriver = name
+	return nil
+}
+
+// SetArtifactDriver overrides the trigger driver used for a single artifact.
+func (r *ArtifactResolver) SetArtifactDriver(artifactName, driverName string) error {
+	if !isRegisteredTriggerDriver(driverName) {
+		return fmt.Errorf("unknown trigger driver: %s", driverName)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.artifactDriver[artifactName] = driverName
+	// Drop any rule-scoped key so the override resolves to its own driver
+	// type instead of a cached instance of the rule's driver.
+	delete(r.artifactDriverKey, artifactName)
+	return nil
+}
+
+// DefaultDriver returns the name of the trigger driver currently used for
+// artifacts without a per-artifact override.
+func (r *ArtifactResolver) DefaultDriver() string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.defaultDriver
+}
+
+// ConfigureDriver sets the debounce window on the typeName driver instance
+// cached under key, if that driver supports it. Callers sharing the same key
+// (e.g. artifacts matched by the same rule) share this setting; callers with
+// distinct keys get independently configured instances even if typeName is
+// the same.
+func (r *ArtifactResolver) ConfigureDriver(key, typeName string, debounce time.Duration) error {
+	driver, err := r.driverFor(key, typeName)
+	if err != nil {
+		return err
+	}
+	if d, ok := driver.(interface{ SetDebounce(time.Duration) }); ok {
+		d.SetDebounce(debounce)
+	}
+	return nil
+}
+
+// ConfigureDriverURL sets the webhook URL on the typeName driver instance
+// cached under key, if that driver supports it. See ConfigureDriver for the
+// key/typeName distinction.
+func (r *ArtifactResolver) ConfigureDriverURL(key, typeName, url string) error {
+	driver, err := r.driverFor(key, typeName)
+	if err != nil {
+		return err
+	}
+	if d, ok := driver.(interface{ SetURL(string) }); ok {
+		d.SetURL(url)
+	}
+	return nil
+}
+
+// SetConfig installs a parsed skaf0.yaml config, applying its resolver-wide
+// defaults immediately. Per-artifact rules are applied lazily as artifacts
+// are discovered via GetDependencies.
+func (r *ArtifactResolver) SetConfig(cfg *Config) error {
+	if cfg.Defaults.Driver != "" {
+		if err := r.SetDefaultDriver(cfg.Defaults.Driver); err != nil {
+			return err
+		}
+	}
+	if cfg.Defaults.Debounce.Duration > 0 {
+		if err := r.ConfigureDriver(r.DefaultDriver(), r.DefaultDriver(), cfg.Defaults.Debounce.Duration); err != nil {
+			return err
+		}
+	}
+	if cfg.Defaults.WebhookURL != "" {
+		if err := r.ConfigureDriverURL(r.DefaultDriver(), r.DefaultDriver(), cfg.Defaults.WebhookURL); err != nil {
+			return err
+		}
+	}
 
+	r.cfgMtx.Lock()
+	r.config = cfg
+	r.cfgMtx.Unlock()
 	return nil
 }
 
+// applyRuleLocked looks up the config rule matching artifactName, if any,
+// and records its driver and autoRebuild selection. The rule's debounce and
+// webhookUrl fall back to the config's Defaults when unset. Callers must
+// hold r.mtx.
+func (r *ArtifactResolver) applyRuleLocked(artifactName string) {
+	r.cfgMtx.RLock()
+	rule := r.config.ruleFor(artifactName)
+	var defaults Defaults
+	if rule != nil {
+		defaults = r.config.Defaults
+	}
+	r.cfgMtx.RUnlock()
+
+	if rule == nil {
+		return
+	}
+
+	r.autoRebuildOverride[artifactName] = rule.autoRebuildEnabled()
+
+	driverName := rule.Driver
+	if driverName == "" {
+		driverName = r.defaultDriver
+	}
+	r.artifactDriver[artifactName] = driverName
+
+	// Key the driver instance by rule, not just driver type: two rules
+	// selecting the same driver (e.g. both "webhook") must not share one
+	// instance, or applying the second rule would silently overwrite the
+	// first rule's URL/debounce for every artifact using it.
+	driverKey := driverName + "#" + rule.Match
+	r.artifactDriverKey[artifactName] = driverKey
+
+	debounce := rule.Debounce.Duration
+	if debounce <= 0 {
+		debounce = defaults.Debounce.Duration
+	}
+	if debounce > 0 {
+		if err := r.ConfigureDriver(driverKey, driverName, debounce); err != nil {
+			fmt.Fprintf(os.Stderr, "skaf0: failed to configure driver %q for %s: %v\n", driverName, artifactName, err)
+		}
+	}
+	webhookURL := rule.WebhookURL
+	if webhookURL == "" {
+		webhookURL = defaults.WebhookURL
+	}
+	if webhookURL != "" {
+		if err := r.ConfigureDriverURL(driverKey, driverName, webhookURL); err != nil {
+			fmt.Fprintf(os.Stderr, "skaf0: failed to configure driver %q for %s: %v\n", driverName, artifactName, err)
+		}
+	}
+}
+
+// autoRebuildEnabled reports whether the given artifact should be rebuilt
+// automatically, defaulting to true for artifacts with no matching rule.
+func (r *ArtifactResolver) autoRebuildEnabled(artifactName string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if enabled, ok := r.autoRebuildOverride[artifactName]; ok {
+		return enabled
+	}
+	return true
+}
+
+// GetDependencies stands in for Skaffold's real dependency resolution: it
+// returns only a.ImageName's synthetic triggerFile, never its actual source
+// files, so this is not a place a Rule's Ignore/Include globs could be
+// applied against anything real (see Rule's doc comment). It does apply
+// applyRuleLocked on first sight of an artifact, so driver/autoRebuild
+// selection is ready before any rebuild is triggered.
 func (r *ArtifactResolver) GetDependencies(ctx context.Context, a *latest.Artifact, cfg docker.Config, tag string) ([]string, error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -176,6 +561,7 @@ func (r *ArtifactResolver) GetDependencies(ctx context.Context, a *latest.Artifa
 			triggerFile: fileName,
 		}
 		r.artifacts[a.ImageName] = art
+		r.applyRuleLocked(a.ImageName)
 	}
 
 	return []string{art.triggerFile}, nil
@@ -64,7 +64,7 @@ func TestTriggerRebuild(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test triggering rebuild
-	err = resolver.TriggerRebuild("test-image")
+	err = resolver.TriggerRebuild(context.Background(), "test-image", "")
 	assert.NoError(t, err)
 
 	// Verify event was sent to channel
@@ -77,7 +77,7 @@ func TestTriggerRebuild(t *testing.T) {
 	}
 
 	// Test triggering non-existent artifact
-	err = resolver.TriggerRebuild("non-existent")
+	err = resolver.TriggerRebuild(context.Background(), "non-existent", "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "artifact not found")
 }
@@ -146,7 +146,7 @@ func TestTriggerRebuilds(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := resolver.TriggerRebuilds(tt.pattern)
+			err := resolver.TriggerRebuilds(context.Background(), tt.pattern, "")
 			if tt.expectError {
 				assert.Error(t, err)
 				return
@@ -156,6 +156,105 @@ func TestTriggerRebuilds(t *testing.T) {
 	}
 }
 
+func TestTriggerRebuildRespectsRuleIgnore(t *testing.T) {
+	resolver := NewArtifactResolver()
+	watchChan := make(chan notify.EventInfo, 10)
+
+	artifact := &latest.Artifact{ImageName: "frontend", Workspace: "frontend-workspace"}
+	_, err := resolver.GetDependencies(context.Background(), artifact, nil, "latest")
+	assert.NoError(t, err)
+	watchPath, err := resolver.WatchPath("frontend-workspace")
+	assert.NoError(t, err)
+	assert.NoError(t, resolver.AddWatch(watchPath, watchChan))
+
+	cfg, err := LoadConfig(writeTempConfig(t, `
+rules:
+  - match: "frontend"
+    ignore: ["*.md"]
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, resolver.SetConfig(cfg))
+
+	// A changed file matching an ignore glob is filtered out: no driver
+	// fires, so nothing is delivered to the watch channel.
+	assert.NoError(t, resolver.TriggerRebuild(context.Background(), "frontend", "CHANGELOG.md"))
+	select {
+	case event := <-watchChan:
+		t.Fatalf("expected rebuild to be filtered by ignore rule, got event %v", event.Path())
+	default:
+	}
+
+	// A changed file that doesn't match ignore goes through as usual.
+	assert.NoError(t, resolver.TriggerRebuild(context.Background(), "frontend", "main.go"))
+	select {
+	case <-watchChan:
+	default:
+		t.Error("expected rebuild to fire for a non-ignored path")
+	}
+}
+
+func TestApplyRuleConfiguresWebhookURL(t *testing.T) {
+	resolver := NewArtifactResolver()
+
+	cfg, err := LoadConfig(writeTempConfig(t, `
+rules:
+  - match: "frontend"
+    driver: webhook
+    webhookUrl: "http://example.invalid/hook"
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, resolver.SetConfig(cfg))
+
+	artifact := &latest.Artifact{ImageName: "frontend", Workspace: "frontend-workspace"}
+	_, err = resolver.GetDependencies(context.Background(), artifact, nil, "latest")
+	assert.NoError(t, err)
+
+	driver, err := resolver.driverFor(resolver.driverKeyForArtifact("frontend"), resolver.driverNameForArtifact("frontend"))
+	assert.NoError(t, err)
+	webhook, ok := driver.(*webhookTriggerDriver)
+	assert.True(t, ok)
+	assert.Equal(t, "http://example.invalid/hook", webhook.url)
+}
+
+// TestApplyRuleGivesEachRuleItsOwnDriverInstance guards against two rules
+// selecting the same driver type stepping on each other's settings: before
+// this fix, driver instances were cached only by type name, so the second
+// rule's webhookUrl silently overwrote the first rule's for every artifact
+// using that driver type.
+func TestApplyRuleGivesEachRuleItsOwnDriverInstance(t *testing.T) {
+	resolver := NewArtifactResolver()
+
+	cfg, err := LoadConfig(writeTempConfig(t, `
+rules:
+  - match: "frontend"
+    driver: webhook
+    webhookUrl: "http://example.invalid/frontend"
+  - match: "backend"
+    driver: webhook
+    webhookUrl: "http://example.invalid/backend"
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, resolver.SetConfig(cfg))
+
+	for _, name := range []string{"frontend", "backend"} {
+		_, err := resolver.GetDependencies(context.Background(), &latest.Artifact{ImageName: name, Workspace: name}, nil, "latest")
+		assert.NoError(t, err)
+	}
+
+	frontendDriver, err := resolver.driverFor(resolver.driverKeyForArtifact("frontend"), resolver.driverNameForArtifact("frontend"))
+	assert.NoError(t, err)
+	backendDriver, err := resolver.driverFor(resolver.driverKeyForArtifact("backend"), resolver.driverNameForArtifact("backend"))
+	assert.NoError(t, err)
+
+	frontendWebhook, ok := frontendDriver.(*webhookTriggerDriver)
+	assert.True(t, ok)
+	backendWebhook, ok := backendDriver.(*webhookTriggerDriver)
+	assert.True(t, ok)
+
+	assert.Equal(t, "http://example.invalid/frontend", frontendWebhook.url)
+	assert.Equal(t, "http://example.invalid/backend", backendWebhook.url)
+}
+
 func TestGetArtifactTriggerFiles(t *testing.T) {
 	resolver := NewArtifactResolver()
 
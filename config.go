@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration that unmarshals from YAML as a Go duration
+// string (e.g. "250ms", "2s").
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	if value.Value == "" {
+		d.Duration = 0
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", value.Value, err)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Rule attaches rebuild behavior to artifacts whose image name matches
+// Match: whether they auto-rebuild at all, which changed files to ignore or
+// require, which TriggerDriver notifies the watcher, and how long that
+// driver should debounce.
+//
+// Ignore and Include only take effect when a rebuild request identifies the
+// file that changed (e.g. /rebuild/<pattern>?path=..., typically from a
+// webhook or editor plugin) — skaf0 has no source-dependency tracking of
+// its own to consult otherwise, so a request with no path is never filtered.
+//
+// WebhookURL only has an effect when Driver (or Defaults.Driver) is
+// "webhook"; it's ignored by every other driver.
+type Rule struct {
+	Match       string   `yaml:"match"`
+	Ignore      []string `yaml:"ignore"`
+	Include     []string `yaml:"include"`
+	Debounce    Duration `yaml:"debounce"`
+	AutoRebuild *bool    `yaml:"autoRebuild"`
+	Driver      string   `yaml:"driver"`
+	WebhookURL  string   `yaml:"webhookUrl"`
+
+	matchGlob    glob.Glob
+	ignoreGlobs  []glob.Glob
+	includeGlobs []glob.Glob
+}
+
+// Defaults holds config values applied resolver-wide, independent of any
+// per-artifact rule.
+type Defaults struct {
+	Debounce   Duration `yaml:"debounce"`
+	Driver     string   `yaml:"driver"`
+	WebhookURL string   `yaml:"webhookUrl"`
+}
+
+// RegistryConfig configures registration with an external service catalog
+// so that several skaf0 instances, each watching its own project on its own
+// -skaf0-addr, can discover one another. Backend selects which of Endpoint,
+// Datacenter (Consul) and Prefix (etcd) apply; Username/Password
+// authenticate against either backend, Token against Consul only.
+type RegistryConfig struct {
+	Backend    string   `yaml:"backend"`
+	Endpoint   string   `yaml:"endpoint"`
+	Datacenter string   `yaml:"datacenter"`
+	Prefix     string   `yaml:"prefix"`
+	Token      string   `yaml:"token"`
+	Username   string   `yaml:"username"`
+	Password   string   `yaml:"password"`
+	TTL        Duration `yaml:"ttl"`
+}
+
+// registryBackends are the Registry implementations selectable via
+// registry.backend.
+var registryBackends = map[string]bool{
+	"consul": true,
+	"etcd":   true,
+}
+
+// Config is the schema for skaf0.yaml: resolver-wide defaults, an ordered
+// list of artifact-matching rules, and optional service-registry settings.
+// For a given artifact, the first rule whose Match glob matches its image
+// name applies.
+type Config struct {
+	Defaults Defaults       `yaml:"defaults"`
+	Rules    []Rule         `yaml:"rules"`
+	Registry RegistryConfig `yaml:"registry"`
+}
+
+// LoadConfig reads and validates a skaf0.yaml file at path, compiling every
+// rule's globs and checking every named driver is registered so that
+// misconfiguration is reported at startup rather than on first use.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if cfg.Defaults.Driver != "" && !isRegisteredTriggerDriver(cfg.Defaults.Driver) {
+		return nil, fmt.Errorf("defaults: unknown trigger driver %q", cfg.Defaults.Driver)
+	}
+
+	if cfg.Registry.Backend != "" && !registryBackends[cfg.Registry.Backend] {
+		return nil, fmt.Errorf("registry: unknown backend %q", cfg.Registry.Backend)
+	}
+
+	for i := range cfg.Rules {
+		if err := cfg.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rule %d (%q): %w", i, cfg.Rules[i].Match, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Match == "" {
+		return fmt.Errorf("match is required")
+	}
+
+	g, err := glob.Compile(r.Match)
+	if err != nil {
+		return fmt.Errorf("invalid match pattern %q: %w", r.Match, err)
+	}
+	r.matchGlob = g
+
+	for _, p := range r.Ignore {
+		g, err := glob.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+		r.ignoreGlobs = append(r.ignoreGlobs, g)
+	}
+
+	for _, p := range r.Include {
+		g, err := glob.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", p, err)
+		}
+		r.includeGlobs = append(r.includeGlobs, g)
+	}
+
+	if r.Driver != "" && !isRegisteredTriggerDriver(r.Driver) {
+		return fmt.Errorf("unknown trigger driver %q", r.Driver)
+	}
+
+	return nil
+}
+
+// allowsPath reports whether a change to path should trigger a rebuild
+// under this rule: excluded if it matches an Ignore glob, or if Include
+// globs are set and none of them match it. An empty path means the caller
+// has no concrete changed file to check — Ignore/Include can't filter what
+// they were never told about, so that always passes.
+func (r *Rule) allowsPath(path string) bool {
+	if path == "" {
+		return true
+	}
+	for _, g := range r.ignoreGlobs {
+		if g.Match(path) {
+			return false
+		}
+	}
+	if len(r.includeGlobs) == 0 {
+		return true
+	}
+	for _, g := range r.includeGlobs {
+		if g.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// autoRebuildEnabled reports whether artifacts matching this rule should be
+// rebuilt automatically, defaulting to true when unset.
+func (r *Rule) autoRebuildEnabled() bool {
+	if r.AutoRebuild == nil {
+		return true
+	}
+	return *r.AutoRebuild
+}
+
+// ruleFor returns the first rule whose Match glob matches imageName, or nil
+// if none do (or no config was loaded).
+func (c *Config) ruleFor(imageName string) *Rule {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Rules {
+		if c.Rules[i].matchGlob.Match(imageName) {
+			return &c.Rules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTempConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "skaf0.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTempConfig(t, `
+defaults:
+  driver: inotify-batch
+  debounce: 100ms
+rules:
+  - match: "frontend-*"
+    ignore: ["*.md"]
+    autoRebuild: false
+  - match: "backend"
+    driver: webhook
+    debounce: 2s
+`)
+
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "inotify-batch", cfg.Defaults.Driver)
+	assert.Len(t, cfg.Rules, 2)
+
+	rule := cfg.ruleFor("frontend-web")
+	assert.NotNil(t, rule)
+	assert.False(t, rule.autoRebuildEnabled())
+
+	assert.Nil(t, cfg.ruleFor("unmatched"))
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "does-not-exist.yaml"))
+	assert.Error(t, err)
+}
+
+func TestLoadConfigUnknownDriver(t *testing.T) {
+	path := writeTempConfig(t, `
+rules:
+  - match: "frontend-*"
+    driver: does-not-exist
+`)
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown trigger driver")
+}
+
+func TestLoadConfigRegistry(t *testing.T) {
+	path := writeTempConfig(t, `
+registry:
+  backend: consul
+  endpoint: 127.0.0.1:8500
+  datacenter: dc1
+  ttl: 10s
+`)
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "consul", cfg.Registry.Backend)
+	assert.Equal(t, "127.0.0.1:8500", cfg.Registry.Endpoint)
+}
+
+func TestLoadConfigUnknownRegistryBackend(t *testing.T) {
+	path := writeTempConfig(t, `
+registry:
+  backend: does-not-exist
+`)
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "registry")
+}
+
+func TestLoadConfigInvalidMatchPattern(t *testing.T) {
+	path := writeTempConfig(t, `
+rules:
+  - match: "[invalid"
+`)
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigRequiresMatch(t *testing.T) {
+	path := writeTempConfig(t, `
+rules:
+  - ignore: ["*.md"]
+`)
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "match is required")
+}
+
+func TestRuleAllowsPath(t *testing.T) {
+	path := writeTempConfig(t, `
+rules:
+  - match: "frontend"
+    ignore: ["*.md", "docs/*"]
+  - match: "backend"
+    include: ["*.go"]
+`)
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+
+	frontend := cfg.ruleFor("frontend")
+	assert.True(t, frontend.allowsPath("main.go"))
+	assert.False(t, frontend.allowsPath("README.md"))
+	assert.False(t, frontend.allowsPath("docs/guide.txt"))
+
+	backend := cfg.ruleFor("backend")
+	assert.True(t, backend.allowsPath("main.go"))
+	assert.False(t, backend.allowsPath("README.md"))
+}
+
+func TestRuleAutoRebuildEnabledDefault(t *testing.T) {
+	r := &Rule{}
+	assert.True(t, r.autoRebuildEnabled())
+}
+
+func TestConfigRuleForNilConfig(t *testing.T) {
+	var cfg *Config
+	assert.Nil(t, cfg.ruleFor("anything"))
+}
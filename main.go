@@ -1,21 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	_ "unsafe"
 
 	"github.com/GoogleContainerTools/skaffold/v2/cmd/skaffold/app"
 	"github.com/GoogleContainerTools/skaffold/v2/pkg/skaffold/docker"
 	"github.com/GoogleContainerTools/skaffold/v2/pkg/skaffold/schema/latest"
+	"github.com/gorilla/websocket"
 	"github.com/manifoldco/promptui"
 	"github.com/rjeczalik/notify"
 )
@@ -40,9 +45,68 @@ func overloaded_Watch(path string, c chan<- notify.EventInfo, events ...notify.E
 var ar *ArtifactResolver
 
 var (
-	flagAddr = flag.String("skaf0-addr", "127.0.0.1:57455", "address to listen on")
+	flagAddr   = flag.String("skaf0-addr", "127.0.0.1:57455", "address to listen on")
+	flagConfig = flag.String("skaf0-config", "", "path to a skaf0.yaml config file")
 )
 
+var wsUpgrader = websocket.Upgrader{
+	// Editor plugins and dashboards may run on a different origin/port.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// serveEvents streams artifact events to the client as Server-Sent Events
+// until the request context is cancelled.
+func serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	id, events := ar.Subscribe()
+	defer ar.Unsubscribe(id)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// serveWS upgrades the connection to a WebSocket and streams artifact
+// events as JSON text frames, for clients that prefer full-duplex over SSE.
+func serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	id, events := ar.Subscribe()
+	defer ar.Unsubscribe(id)
+
+	for event := range events {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+}
+
 func ctrl(args []string) error {
 	if len(args) == 0 {
 		fmt.Println("Usage: skaf0 ctrl <command>")
@@ -50,6 +114,11 @@ func ctrl(args []string) error {
 		fmt.Println("  list     - List all available artifacts")
 		fmt.Println("  rebuild  - Rebuild specific artifacts. Usage: rebuild <pattern1> [<pattern2> ...]")
 		fmt.Println("             Patterns can be artifact names or wildcards like 'frontend-*' or '*'")
+		fmt.Println("             With a registry: block configured, routes to the instance that")
+		fmt.Println("             advertises the first pattern as an artifact name, if exactly one does.")
+		fmt.Println("  tail     - Stream live build and file-change events")
+		fmt.Println("  discover - List running skaf0 instances and their artifacts (requires a")
+		fmt.Println("             registry: block in -skaf0-config)")
 		return fmt.Errorf("Usage: skaf0 ctrl <command>")
 	}
 
@@ -71,12 +140,84 @@ func ctrl(args []string) error {
 		}
 		return nil
 	case "rebuild":
-		return rebuildArtifacts(args[1:], baseURL)
+		targetURL := rebuildTargetURL(args[1:], baseURL)
+		return rebuildArtifacts(args[1:], targetURL)
+	case "tail":
+		return tailEvents(baseURL)
+	case "discover":
+		return discoverInstances()
 	default:
 		return fmt.Errorf("unknown command: %s", command)
 	}
 }
 
+// registryFromFlags builds a Registry client from the skaf0.yaml named by
+// -skaf0-config, the same config file the running instances loaded.
+func registryFromFlags() (Registry, error) {
+	if *flagConfig == "" {
+		return nil, fmt.Errorf("requires -skaf0-config pointing at the skaf0.yaml with a registry: block")
+	}
+	cfg, err := LoadConfig(*flagConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error loading skaf0 config: %w", err)
+	}
+	if cfg.Registry.Backend == "" {
+		return nil, fmt.Errorf("%s has no registry: block configured", *flagConfig)
+	}
+	return newRegistry(cfg.Registry)
+}
+
+// discoverInstances queries the configured service registry and prints
+// every running skaf0 instance along with the artifacts it advertises.
+func discoverInstances() error {
+	reg, err := registryFromFlags()
+	if err != nil {
+		return err
+	}
+
+	instances, err := reg.Discover()
+	if err != nil {
+		return fmt.Errorf("error discovering instances: %w", err)
+	}
+
+	for _, inst := range instances {
+		fmt.Printf("%s\t%s\t%s\n", inst.ServiceID, inst.Addr, strings.Join(inst.Artifacts, ","))
+	}
+	return nil
+}
+
+// rebuildTargetURL picks which skaf0 instance to send a rebuild request to:
+// if a registry is configured and exactly one running instance advertises
+// the first requested pattern, route directly to it; otherwise fall back to
+// the local instance at baseURL.
+func rebuildTargetURL(patterns []string, baseURL string) string {
+	if len(patterns) == 0 {
+		return baseURL
+	}
+
+	reg, err := registryFromFlags()
+	if err != nil {
+		return baseURL
+	}
+
+	instances, err := reg.Discover()
+	if err != nil {
+		return baseURL
+	}
+
+	var matches []Instance
+	for _, inst := range instances {
+		if slices.Contains(inst.Artifacts, patterns[0]) {
+			matches = append(matches, inst)
+		}
+	}
+
+	if len(matches) == 1 {
+		return fmt.Sprintf("http://%s", matches[0].Addr)
+	}
+	return baseURL
+}
+
 func selectArtifact(baseURL string) (string, error) {
 	artifacts, err := fetchArtifacts(baseURL)
 	if err != nil {
@@ -98,6 +239,10 @@ func selectArtifact(baseURL string) (string, error) {
 	return result, nil
 }
 
+// rebuildPollInterval is how often `ctrl rebuild` polls a submitted job's
+// status while it's running.
+const rebuildPollInterval = 250 * time.Millisecond
+
 func rebuildArtifacts(args []string, baseURL string) error {
 	if len(args) < 1 {
 		artifact, err := selectArtifact(baseURL)
@@ -110,12 +255,95 @@ func rebuildArtifacts(args []string, baseURL string) error {
 	artifacts := strings.Join(args, ",")
 	encodedArtifacts := url.QueryEscape(artifacts)
 
-	resp, err := http.Get(baseURL + "/rebuild/" + encodedArtifacts)
+	resp, err := http.Post(baseURL+"/rebuild/"+encodedArtifacts, "application/json", nil)
 	if err != nil {
 		return fmt.Errorf("error triggering rebuild: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusAccepted {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("error reading response: %w", err)
+		}
+		return fmt.Errorf("error response from skaf0: %s - %s", resp.Status, body)
+	}
+
+	var submitted struct {
+		JobID     string   `json:"jobId"`
+		Artifacts []string `json:"artifacts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&submitted); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	fmt.Printf("Rebuild job %s submitted for artifacts: %s\n", submitted.JobID, strings.Join(submitted.Artifacts, ", "))
+	return pollRebuildJob(baseURL, submitted.JobID)
+}
+
+// pollRebuildJob polls a submitted rebuild job until it leaves the running
+// state, printing each artifact's status as it changes.
+func pollRebuildJob(baseURL, jobID string) error {
+	printed := make(map[string]JobState)
+
+	for {
+		status, err := fetchJobStatus(baseURL, jobID)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range status.Artifacts {
+			if s := status.ArtifactStatus[name]; s != printed[name] {
+				fmt.Printf("  %s: %s\n", name, s)
+				printed[name] = s
+			}
+		}
+
+		switch status.State {
+		case JobSucceeded:
+			fmt.Println("Rebuild job succeeded")
+			return nil
+		case JobFailed:
+			return fmt.Errorf("rebuild job failed: %s", status.Error)
+		case JobCancelled:
+			return fmt.Errorf("rebuild job cancelled")
+		}
+
+		time.Sleep(rebuildPollInterval)
+	}
+}
+
+func fetchJobStatus(baseURL, jobID string) (RebuildJobStatus, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/jobs/%s", baseURL, jobID))
+	if err != nil {
+		return RebuildJobStatus{}, fmt.Errorf("error polling job: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return RebuildJobStatus{}, fmt.Errorf("error reading response: %w", err)
+		}
+		return RebuildJobStatus{}, fmt.Errorf("error response from skaf0: %s - %s", resp.Status, body)
+	}
+
+	var status RebuildJobStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return RebuildJobStatus{}, fmt.Errorf("error decoding job status: %w", err)
+	}
+	return status, nil
+}
+
+// tailEvents connects to the /events SSE stream and prints each event as it
+// arrives, mirroring the line-oriented progress output of `skaffold dev`.
+func tailEvents(baseURL string) error {
+	resp, err := http.Get(baseURL + "/events")
+	if err != nil {
+		return fmt.Errorf("error connecting to event stream: %w", err)
+	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -124,8 +352,22 @@ func rebuildArtifacts(args []string, baseURL string) error {
 		return fmt.Errorf("error response from skaf0: %s - %s", resp.Status, body)
 	}
 
-	fmt.Printf("Rebuild triggered for artifacts: %s\n", artifacts)
-	return nil
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			fmt.Fprintf(os.Stderr, "error decoding event: %v\n", err)
+			continue
+		}
+		fmt.Printf("[%s] %s %s %s\n", event.Timestamp.Format("15:04:05"), event.ArtifactName, event.Kind, event.Path)
+	}
+	return scanner.Err()
 }
 
 func fetchArtifacts(baseURL string) ([]string, error) {
@@ -147,6 +389,54 @@ func fetchArtifacts(baseURL string) ([]string, error) {
 	return artifacts, nil
 }
 
+// registryHeartbeatInterval is how often the background goroutine renews
+// the TTL health check and refreshes this instance's advertised artifact
+// tags. Kept well under the backend's TTL so a single missed tick doesn't
+// flap the instance's health.
+const registryHeartbeatInterval = 5 * time.Second
+
+// registerWithRegistry registers this instance with the service catalog
+// named by regCfg and starts a background goroutine that heartbeats it and
+// refreshes its advertised artifacts. It returns a deregister func that the
+// caller must run once app.Run has returned: Skaffold installs its own
+// SIGINT/SIGTERM handling to tear down port-forwards and dev-mode resources,
+// and a second signal handler racing it to os.Exit here could win and kill
+// the process before that cleanup finishes.
+func registerWithRegistry(regCfg RegistryConfig, addr string) func() {
+	reg, err := newRegistry(regCfg)
+	if err != nil {
+		fmt.Println("Error creating registry client:", err)
+		os.Exit(1)
+	}
+
+	serviceID := fmt.Sprintf("skaf0@%s", addr)
+	if err := reg.Register(serviceID, addr, ar.GetArtifacts()); err != nil {
+		fmt.Println("Error registering with service registry:", err)
+		os.Exit(1)
+	}
+
+	go func() {
+		ticker := time.NewTicker(registryHeartbeatInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := reg.Register(serviceID, addr, ar.GetArtifacts()); err != nil {
+				fmt.Fprintln(os.Stderr, "skaf0: failed to refresh registry registration:", err)
+				continue
+			}
+			if err := reg.Heartbeat(serviceID); err != nil {
+				fmt.Fprintln(os.Stderr, "skaf0: failed to heartbeat registry registration:", err)
+			}
+		}
+	}()
+
+	return func() {
+		if err := reg.Deregister(serviceID); err != nil {
+			fmt.Fprintln(os.Stderr, "skaf0: failed to deregister from service registry:", err)
+		}
+	}
+}
+
 func main() {
 	flag.Parse()
 
@@ -163,6 +453,31 @@ func main() {
 
 	ar = NewArtifactResolver()
 
+	var cfg *Config
+	if *flagConfig != "" {
+		var err error
+		cfg, err = LoadConfig(*flagConfig)
+		if err != nil {
+			fmt.Println("Error loading skaf0 config:", err)
+			os.Exit(1)
+		}
+		if err := ar.SetConfig(cfg); err != nil {
+			fmt.Println("Error applying skaf0 config:", err)
+			os.Exit(1)
+		}
+	}
+
+	listener, err := net.Listen("tcp", *flagAddr)
+	if err != nil {
+		fmt.Println("Error starting listener:", err)
+		os.Exit(1)
+	}
+
+	var deregister func()
+	if cfg != nil && cfg.Registry.Backend != "" {
+		deregister = registerWithRegistry(cfg.Registry, listener.Addr().String())
+	}
+
 	go func() {
 		mux := http.NewServeMux()
 		mux.HandleFunc("/triggers", func(w http.ResponseWriter, r *http.Request) {
@@ -177,18 +492,71 @@ func main() {
 			watches := ar.GetWatches()
 			json.NewEncoder(w).Encode(watches)
 		})
+		mux.HandleFunc("/events", serveEvents)
+		mux.HandleFunc("/ws", serveWS)
 		mux.HandleFunc("/rebuild/", func(w http.ResponseWriter, r *http.Request) {
-			artifact := strings.TrimPrefix(r.URL.Path, "/rebuild/")
-			if err := ar.TriggerRebuilds(artifact); err != nil {
+			pattern := strings.TrimPrefix(r.URL.Path, "/rebuild/")
+			changedPath := r.URL.Query().Get("path")
+
+			if r.URL.Query().Get("wait") == "true" {
+				if err := ar.TriggerRebuilds(r.Context(), pattern, changedPath); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			var timeout time.Duration
+			if raw := r.URL.Query().Get("timeout"); raw != "" {
+				parsed, err := time.ParseDuration(raw)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid timeout %q: %v", raw, err), http.StatusBadRequest)
+					return
+				}
+				timeout = parsed
+			}
+
+			job, err := ar.SubmitRebuildJob(pattern, changedPath, timeout)
+			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			w.WriteHeader(http.StatusOK)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(struct {
+				JobID     string   `json:"jobId"`
+				Artifacts []string `json:"artifacts"`
+			}{JobID: job.ID, Artifacts: job.Artifacts})
 		})
-		http.ListenAndServe(*flagAddr, mux)
+		mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(ar.GetJobs())
+		})
+		mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
+			id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+			job, ok := ar.GetJob(id)
+			if !ok {
+				http.Error(w, "job not found: "+id, http.StatusNotFound)
+				return
+			}
+
+			if r.Method == http.MethodDelete {
+				job.Cancel()
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			json.NewEncoder(w).Encode(job.Status())
+		})
+		http.Serve(listener, mux)
 	}()
 
 	if err := app.Run(os.Stdout, os.Stderr); err != nil {
 		fmt.Println("Error executing skaffold dev", err)
 	}
+
+	if deregister != nil {
+		deregister()
+	}
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JobState is the lifecycle state of a RebuildJob or one of its per-artifact
+// entries.
+type JobState string
+
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// jobDeadline mirrors the deadlineTimer pattern used by gvisor's netstack: a
+// channel that is closed exactly once, either by an expiring time.AfterFunc
+// timer or by an explicit cancel, so any number of goroutines can safely
+// select on it concurrently without a duplicate-close panic.
+type jobDeadline struct {
+	mtx   sync.Mutex
+	timer *time.Timer
+	ch    chan struct{}
+}
+
+func newJobDeadline() *jobDeadline {
+	return &jobDeadline{ch: make(chan struct{})}
+}
+
+// after arms the deadline to fire once timeout elapses. A non-positive
+// timeout leaves the deadline armed only by an explicit cancel.
+func (d *jobDeadline) after(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.timer = time.AfterFunc(timeout, d.fire)
+}
+
+// fire closes the deadline's channel, if it isn't already closed, and stops
+// any pending timer.
+func (d *jobDeadline) fire() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	select {
+	case <-d.ch:
+	default:
+		close(d.ch)
+	}
+}
+
+func (d *jobDeadline) done() <-chan struct{} {
+	return d.ch
+}
+
+// RebuildJob tracks one asynchronous TriggerRebuilds run submitted via
+// POST /rebuild/<pattern>, including per-artifact outcomes, so a client can
+// poll progress instead of blocking on the HTTP request for the whole
+// duration of the rebuild.
+type RebuildJob struct {
+	ID        string
+	Pattern   string
+	Artifacts []string
+	StartedAt time.Time
+
+	changedPath string
+	deadline    *jobDeadline
+	cancel      context.CancelFunc
+	done        chan struct{}
+
+	mtx            sync.Mutex
+	state          JobState
+	artifactStatus map[string]JobState
+	endedAt        time.Time
+	err            error
+}
+
+// RebuildJobStatus is the JSON-serializable snapshot of a RebuildJob,
+// returned by GET /jobs and GET /jobs/{id}.
+type RebuildJobStatus struct {
+	ID             string              `json:"jobId"`
+	Pattern        string              `json:"pattern"`
+	Artifacts      []string            `json:"artifacts"`
+	State          JobState            `json:"state"`
+	StartedAt      time.Time           `json:"startedAt"`
+	EndedAt        *time.Time          `json:"endedAt,omitempty"`
+	ArtifactStatus map[string]JobState `json:"artifactStatus"`
+	Error          string              `json:"error,omitempty"`
+}
+
+// Status returns a snapshot of the job's current state, safe to call while
+// the job is still running.
+func (j *RebuildJob) Status() RebuildJobStatus {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	status := RebuildJobStatus{
+		ID:             j.ID,
+		Pattern:        j.Pattern,
+		Artifacts:      j.Artifacts,
+		State:          j.state,
+		StartedAt:      j.StartedAt,
+		ArtifactStatus: maps.Clone(j.artifactStatus),
+	}
+	if !j.endedAt.IsZero() {
+		endedAt := j.endedAt
+		status.EndedAt = &endedAt
+	}
+	if j.err != nil {
+		status.Error = j.err.Error()
+	}
+	return status
+}
+
+// Cancel stops the job: artifacts whose rebuild has not started yet are
+// skipped and reported as cancelled. An artifact whose TriggerRebuild call is
+// already in flight sees its context cancelled, but its status is whatever
+// that call returns (succeeded or failed). Safe to call more than once, and
+// safe to call after the job has already finished.
+func (j *RebuildJob) Cancel() {
+	j.deadline.fire()
+	j.cancel()
+}
+
+// Wait blocks until the job finishes, is cancelled (via DELETE or its
+// ?timeout=), or ctx is done — whichever happens first. Multiple callers
+// may Wait on the same job concurrently.
+func (j *RebuildJob) Wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-j.deadline.done():
+	case <-j.done:
+	}
+}
+
+func (j *RebuildJob) setArtifactStatus(name string, state JobState) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.artifactStatus[name] = state
+}
+
+// buildEventKinds maps a per-artifact JobState to the Event published
+// alongside it, so subscribers can follow job progress without polling.
+var buildEventKinds = map[JobState]EventKind{
+	JobRunning:   EventBuildRunning,
+	JobSucceeded: EventBuildSucceeded,
+	JobFailed:    EventBuildFailed,
+	JobCancelled: EventBuildCancelled,
+}
+
+// setArtifactStatusAndPublish updates the job's per-artifact status and
+// publishes the matching build-lifecycle event for it.
+func (r *ArtifactResolver) setArtifactStatusAndPublish(job *RebuildJob, artifactName string, state JobState) {
+	job.setArtifactStatus(artifactName, state)
+	if kind, ok := buildEventKinds[state]; ok {
+		r.publish(Event{ArtifactName: artifactName, Kind: kind, Timestamp: time.Now()})
+	}
+}
+
+func (j *RebuildJob) finish(state JobState, err error) {
+	j.mtx.Lock()
+	j.state = state
+	j.endedAt = time.Now()
+	if err != nil {
+		j.err = err
+	}
+	j.mtx.Unlock()
+	close(j.done)
+}
+
+// SubmitRebuildJob resolves pattern to a set of currently-known artifacts
+// and rebuilds them one by one in a background goroutine, returning
+// immediately with a RebuildJob that can be polled via GetJob or awaited via
+// RebuildJob.Wait. changedPath is forwarded to each artifact's TriggerRebuild
+// call for Ignore/Include filtering (see ArtifactResolver.TriggerRebuild);
+// pass "" when the caller has no specific changed file to report. A positive
+// timeout cancels the remaining work once it elapses, same as an explicit
+// Cancel.
+func (r *ArtifactResolver) SubmitRebuildJob(pattern, changedPath string, timeout time.Duration) (*RebuildJob, error) {
+	artifacts, err := r.resolvePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &RebuildJob{
+		ID:             r.nextJobID(),
+		Pattern:        pattern,
+		Artifacts:      artifacts,
+		StartedAt:      time.Now(),
+		changedPath:    changedPath,
+		state:          JobRunning,
+		artifactStatus: make(map[string]JobState, len(artifacts)),
+		deadline:       newJobDeadline(),
+		cancel:         cancel,
+		done:           make(chan struct{}),
+	}
+	for _, a := range artifacts {
+		job.artifactStatus[a] = JobPending
+		r.publish(Event{ArtifactName: a, Kind: EventBuildQueued, Timestamp: time.Now()})
+	}
+	job.deadline.after(timeout)
+
+	r.jobsMtx.Lock()
+	r.jobs[job.ID] = job
+	r.jobsMtx.Unlock()
+
+	// Tie the deadline (timeout or explicit Cancel) to the job's context, so
+	// an in-flight TriggerRebuild call observes ctx.Done() the same way it
+	// would for a caller-supplied deadline.
+	go func() {
+		select {
+		case <-job.deadline.done():
+			cancel()
+		case <-job.done:
+		}
+	}()
+
+	go r.runRebuildJob(ctx, job)
+
+	return job, nil
+}
+
+func (r *ArtifactResolver) runRebuildJob(ctx context.Context, job *RebuildJob) {
+	var firstErr error
+	cancelled := false
+
+	for _, artifactName := range job.Artifacts {
+		if ctx.Err() != nil {
+			cancelled = true
+			r.setArtifactStatusAndPublish(job, artifactName, JobCancelled)
+			continue
+		}
+
+		r.setArtifactStatusAndPublish(job, artifactName, JobRunning)
+		if err := r.TriggerRebuild(ctx, artifactName, job.changedPath); err != nil {
+			r.setArtifactStatusAndPublish(job, artifactName, JobFailed)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		r.setArtifactStatusAndPublish(job, artifactName, JobSucceeded)
+	}
+
+	switch {
+	case cancelled:
+		job.finish(JobCancelled, firstErr)
+	case firstErr != nil:
+		job.finish(JobFailed, firstErr)
+	default:
+		job.finish(JobSucceeded, nil)
+	}
+}
+
+// nextJobID returns a fresh, process-unique job id.
+func (r *ArtifactResolver) nextJobID() string {
+	r.jobsMtx.Lock()
+	defer r.jobsMtx.Unlock()
+	r.nextJobSeq++
+	return strconv.Itoa(r.nextJobSeq)
+}
+
+// GetJob returns the job registered under id, if any.
+func (r *ArtifactResolver) GetJob(id string) (*RebuildJob, bool) {
+	r.jobsMtx.Lock()
+	defer r.jobsMtx.Unlock()
+	job, ok := r.jobs[id]
+	return job, ok
+}
+
+// GetJobs returns a snapshot of every job's current status, in no particular
+// order.
+func (r *ArtifactResolver) GetJobs() []RebuildJobStatus {
+	r.jobsMtx.Lock()
+	jobs := make([]*RebuildJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	r.jobsMtx.Unlock()
+
+	statuses := make([]RebuildJobStatus, len(jobs))
+	for i, job := range jobs {
+		statuses[i] = job.Status()
+	}
+	return statuses
+}
+
+// CancelJob cancels the job registered under id. It reports whether a job
+// with that id was found.
+func (r *ArtifactResolver) CancelJob(id string) bool {
+	job, ok := r.GetJob(id)
+	if !ok {
+		return false
+	}
+	job.Cancel()
+	return true
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/v2/pkg/skaffold/schema/latest"
+	"github.com/rjeczalik/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+// registerArtifacts registers each named artifact and a watch for it, so
+// TriggerRebuild's default write-trigger driver has somewhere to deliver its
+// synthetic file-change event instead of erroring with "watch not found".
+func registerArtifacts(t *testing.T, resolver *ArtifactResolver, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		_, err := resolver.GetDependencies(context.Background(), &latest.Artifact{ImageName: name, Workspace: name}, nil, "latest")
+		assert.NoError(t, err)
+
+		watchPath, err := resolver.WatchPath(name)
+		assert.NoError(t, err)
+		assert.NoError(t, resolver.AddWatch(watchPath, make(chan notify.EventInfo, 1)))
+	}
+}
+
+func TestSubmitRebuildJobSucceeds(t *testing.T) {
+	resolver := NewArtifactResolver()
+	registerArtifacts(t, resolver, "frontend", "backend")
+
+	job, err := resolver.SubmitRebuildJob("*", "", 0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"frontend", "backend"}, job.Artifacts)
+
+	job.Wait(context.Background())
+
+	status := job.Status()
+	assert.Equal(t, JobSucceeded, status.State)
+	assert.Equal(t, JobSucceeded, status.ArtifactStatus["frontend"])
+	assert.Equal(t, JobSucceeded, status.ArtifactStatus["backend"])
+	assert.NotNil(t, status.EndedAt)
+}
+
+func TestSubmitRebuildJobNoMatch(t *testing.T) {
+	resolver := NewArtifactResolver()
+	_, err := resolver.SubmitRebuildJob("nonexistent", "", 0)
+	assert.Error(t, err)
+}
+
+func TestGetJob(t *testing.T) {
+	resolver := NewArtifactResolver()
+	registerArtifacts(t, resolver, "frontend")
+
+	job, err := resolver.SubmitRebuildJob("frontend", "", 0)
+	assert.NoError(t, err)
+	job.Wait(context.Background())
+
+	found, ok := resolver.GetJob(job.ID)
+	assert.True(t, ok)
+	assert.Equal(t, job.ID, found.ID)
+
+	_, ok = resolver.GetJob("does-not-exist")
+	assert.False(t, ok)
+}
+
+func TestCancelJob(t *testing.T) {
+	resolver := NewArtifactResolver()
+	registerArtifacts(t, resolver, "frontend")
+
+	job, err := resolver.SubmitRebuildJob("frontend", "", 0)
+	assert.NoError(t, err)
+
+	assert.True(t, resolver.CancelJob(job.ID))
+	job.Wait(context.Background())
+
+	assert.False(t, resolver.CancelJob("does-not-exist"))
+}
+
+func TestRebuildJobTimeout(t *testing.T) {
+	job := &RebuildJob{
+		ID:       "1",
+		deadline: newJobDeadline(),
+		done:     make(chan struct{}),
+	}
+	_, cancel := context.WithCancel(context.Background())
+	job.cancel = cancel
+	job.deadline.after(10 * time.Millisecond)
+
+	select {
+	case <-job.deadline.done():
+	case <-time.After(time.Second):
+		t.Fatal("deadline did not fire")
+	}
+}
+
+func TestJobDeadlineFireIsIdempotent(t *testing.T) {
+	d := newJobDeadline()
+	assert.NotPanics(t, func() {
+		d.fire()
+		d.fire()
+	})
+}
+
+func TestSubmitRebuildJobPublishesLifecycleEvents(t *testing.T) {
+	resolver := NewArtifactResolver()
+	registerArtifacts(t, resolver, "frontend")
+
+	_, events := resolver.Subscribe()
+
+	job, err := resolver.SubmitRebuildJob("frontend", "", 0)
+	assert.NoError(t, err)
+	job.Wait(context.Background())
+
+	var kinds []EventKind
+	for len(kinds) < 5 {
+		select {
+		case e := <-events:
+			kinds = append(kinds, e.Kind)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for lifecycle events, got %v so far", kinds)
+		}
+	}
+
+	assert.Equal(t, []EventKind{
+		EventBuildQueued,
+		EventBuildRunning,
+		EventFileChange,
+		EventRebuildTriggered,
+		EventBuildSucceeded,
+	}, kinds)
+}
+
+func TestGetJobs(t *testing.T) {
+	resolver := NewArtifactResolver()
+	registerArtifacts(t, resolver, "frontend", "backend")
+
+	job, err := resolver.SubmitRebuildJob("*", "", 0)
+	assert.NoError(t, err)
+	job.Wait(context.Background())
+
+	jobs := resolver.GetJobs()
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+}
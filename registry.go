@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// skaf0ServiceName is the service name every skaf0 instance registers
+// under, regardless of backend.
+const skaf0ServiceName = "skaf0"
+
+// defaultRegistryTTL is the health-check TTL used when RegistryConfig.TTL
+// is unset.
+const defaultRegistryTTL = 15 * time.Second
+
+// registryRequestTimeout bounds individual Registry calls against the
+// catalog backend.
+const registryRequestTimeout = 5 * time.Second
+
+// Instance is one running skaf0 process as seen through a Registry.
+type Instance struct {
+	ServiceID string
+	Addr      string
+	Artifacts []string
+}
+
+// Registry lets a skaf0 instance announce itself, and other instances
+// discover each other, via an external service catalog. Implementations are
+// selected by RegistryConfig.Backend via newRegistry.
+type Registry interface {
+	// Register advertises this instance under serviceID at addr, tagged
+	// with one tag per currently-known artifact image name. Safe to call
+	// repeatedly to refresh the advertised artifacts.
+	Register(serviceID, addr string, artifacts []string) error
+	// Heartbeat renews the TTL health check registered for serviceID.
+	// Callers invoke it repeatedly from a background goroutine, faster
+	// than the backend's TTL, for as long as the process runs.
+	Heartbeat(serviceID string) error
+	// Deregister removes serviceID from the catalog. Called once on
+	// shutdown.
+	Deregister(serviceID string) error
+	// Discover lists every currently-registered skaf0 instance.
+	Discover() ([]Instance, error)
+}
+
+// newRegistry constructs the Registry implementation named by cfg.Backend.
+// LoadConfig already validates Backend against registryBackends, so an
+// unknown value here means newRegistry was called directly on an
+// unvalidated config.
+func newRegistry(cfg RegistryConfig) (Registry, error) {
+	ttl := defaultRegistryTTL
+	if cfg.TTL.Duration > 0 {
+		ttl = cfg.TTL.Duration
+	}
+
+	switch cfg.Backend {
+	case "consul":
+		return newConsulRegistry(cfg, ttl)
+	case "etcd":
+		return newEtcdRegistry(cfg, ttl)
+	default:
+		return nil, fmt.Errorf("unknown registry backend %q", cfg.Backend)
+	}
+}
+
+// consulAgent is the subset of *consulapi.Client.Agent() that consulRegistry
+// needs. *consulapi.Agent satisfies it, so production code passes that
+// straight through; tests can substitute a fake without a live Consul agent.
+type consulAgent interface {
+	ServiceRegister(*consulapi.AgentServiceRegistration) error
+	UpdateTTL(checkID, output, status string) error
+	ServiceDeregister(serviceID string) error
+	ServicesWithFilter(filter string) (map[string]*consulapi.AgentService, error)
+}
+
+// consulRegistry implements Registry against a Consul agent, advertising
+// each skaf0 instance as a service with one tag per artifact and a TTL
+// check that the heartbeat goroutine keeps passing.
+type consulRegistry struct {
+	agent consulAgent
+	ttl   time.Duration
+}
+
+func newConsulRegistry(cfg RegistryConfig, ttl time.Duration) (*consulRegistry, error) {
+	apiCfg := consulapi.DefaultConfig()
+	if cfg.Endpoint != "" {
+		apiCfg.Address = cfg.Endpoint
+	}
+	if cfg.Datacenter != "" {
+		apiCfg.Datacenter = cfg.Datacenter
+	}
+	if cfg.Token != "" {
+		apiCfg.Token = cfg.Token
+	}
+	if cfg.Username != "" {
+		apiCfg.HttpAuth = &consulapi.HttpBasicAuth{Username: cfg.Username, Password: cfg.Password}
+	}
+
+	client, err := consulapi.NewClient(apiCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client: %w", err)
+	}
+	return &consulRegistry{agent: client.Agent(), ttl: ttl}, nil
+}
+
+func (c *consulRegistry) Register(serviceID, addr string, artifacts []string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid port in address %q: %w", addr, err)
+	}
+
+	return c.agent.ServiceRegister(&consulapi.AgentServiceRegistration{
+		ID:      serviceID,
+		Name:    skaf0ServiceName,
+		Tags:    artifacts,
+		Address: host,
+		Port:    port,
+		Check: &consulapi.AgentServiceCheck{
+			TTL:                            c.ttl.String(),
+			DeregisterCriticalServiceAfter: (3 * c.ttl).String(),
+		},
+	})
+}
+
+func (c *consulRegistry) Heartbeat(serviceID string) error {
+	return c.agent.UpdateTTL("service:"+serviceID, "", consulapi.HealthPassing)
+}
+
+func (c *consulRegistry) Deregister(serviceID string) error {
+	return c.agent.ServiceDeregister(serviceID)
+}
+
+func (c *consulRegistry) Discover() ([]Instance, error) {
+	services, err := c.agent.ServicesWithFilter(fmt.Sprintf("Service == %q", skaf0ServiceName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consul services: %w", err)
+	}
+
+	instances := make([]Instance, 0, len(services))
+	for id, svc := range services {
+		instances = append(instances, Instance{
+			ServiceID: id,
+			Addr:      net.JoinHostPort(svc.Address, strconv.Itoa(svc.Port)),
+			Artifacts: svc.Tags,
+		})
+	}
+	return instances, nil
+}
+
+// etcdInstanceValue is the JSON value stored under prefix+serviceID,
+// leased so it expires if the owning instance stops heartbeating.
+type etcdInstanceValue struct {
+	Addr      string   `json:"addr"`
+	Artifacts []string `json:"artifacts"`
+}
+
+// etcdKV is the subset of *clientv3.Client that etcdRegistry needs.
+// *clientv3.Client satisfies it, so production code passes that straight
+// through; tests can substitute a fake without a live etcd cluster.
+type etcdKV interface {
+	Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error)
+	Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error)
+	KeepAliveOnce(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error)
+	Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error)
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+}
+
+// etcdRegistry implements Registry against etcd, storing one leased key per
+// instance under Prefix (default "/skaf0/instances/").
+type etcdRegistry struct {
+	client etcdKV
+	prefix string
+	ttl    time.Duration
+
+	mtx    sync.Mutex
+	leases map[string]clientv3.LeaseID
+}
+
+func newEtcdRegistry(cfg RegistryConfig, ttl time.Duration) (*etcdRegistry, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{cfg.Endpoint},
+		DialTimeout: registryRequestTimeout,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "/skaf0/instances/"
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return &etcdRegistry{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+		leases: make(map[string]clientv3.LeaseID),
+	}, nil
+}
+
+func (e *etcdRegistry) Register(serviceID, addr string, artifacts []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), registryRequestTimeout)
+	defer cancel()
+
+	lease, err := e.client.Grant(ctx, int64(e.ttl.Seconds()))
+	if err != nil {
+		return fmt.Errorf("failed to create etcd lease: %w", err)
+	}
+
+	value, err := json.Marshal(etcdInstanceValue{Addr: addr, Artifacts: artifacts})
+	if err != nil {
+		return fmt.Errorf("failed to marshal instance value: %w", err)
+	}
+
+	if _, err := e.client.Put(ctx, e.prefix+serviceID, string(value), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to register with etcd: %w", err)
+	}
+
+	e.mtx.Lock()
+	e.leases[serviceID] = lease.ID
+	e.mtx.Unlock()
+	return nil
+}
+
+func (e *etcdRegistry) Heartbeat(serviceID string) error {
+	e.mtx.Lock()
+	lease, ok := e.leases[serviceID]
+	e.mtx.Unlock()
+	if !ok {
+		return fmt.Errorf("no active etcd lease for %s; call Register first", serviceID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), registryRequestTimeout)
+	defer cancel()
+
+	_, err := e.client.KeepAliveOnce(ctx, lease)
+	return err
+}
+
+func (e *etcdRegistry) Deregister(serviceID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), registryRequestTimeout)
+	defer cancel()
+
+	_, err := e.client.Delete(ctx, e.prefix+serviceID)
+
+	e.mtx.Lock()
+	delete(e.leases, serviceID)
+	e.mtx.Unlock()
+	return err
+}
+
+func (e *etcdRegistry) Discover() ([]Instance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), registryRequestTimeout)
+	defer cancel()
+
+	resp, err := e.client.Get(ctx, e.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd instances: %w", err)
+	}
+
+	instances := make([]Instance, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var value etcdInstanceValue
+		if err := json.Unmarshal(kv.Value, &value); err != nil {
+			continue
+		}
+		instances = append(instances, Instance{
+			ServiceID: strings.TrimPrefix(string(kv.Key), e.prefix),
+			Addr:      value.Addr,
+			Artifacts: value.Artifacts,
+		})
+	}
+	return instances, nil
+}
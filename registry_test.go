@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeConsulAgent is an in-memory consulAgent, letting registry tests
+// exercise a real Register/Discover/Deregister round trip without a live
+// Consul agent.
+type fakeConsulAgent struct {
+	services map[string]*consulapi.AgentServiceRegistration
+}
+
+func newFakeConsulAgent() *fakeConsulAgent {
+	return &fakeConsulAgent{services: make(map[string]*consulapi.AgentServiceRegistration)}
+}
+
+func (f *fakeConsulAgent) ServiceRegister(reg *consulapi.AgentServiceRegistration) error {
+	f.services[reg.ID] = reg
+	return nil
+}
+
+func (f *fakeConsulAgent) UpdateTTL(checkID, output, status string) error {
+	id := checkID[len("service:"):]
+	if _, ok := f.services[id]; !ok {
+		return fmt.Errorf("unknown service %q", id)
+	}
+	return nil
+}
+
+func (f *fakeConsulAgent) ServiceDeregister(serviceID string) error {
+	delete(f.services, serviceID)
+	return nil
+}
+
+func (f *fakeConsulAgent) ServicesWithFilter(filter string) (map[string]*consulapi.AgentService, error) {
+	result := make(map[string]*consulapi.AgentService, len(f.services))
+	for id, reg := range f.services {
+		result[id] = &consulapi.AgentService{
+			ID:      reg.ID,
+			Service: reg.Name,
+			Tags:    reg.Tags,
+			Address: reg.Address,
+			Port:    reg.Port,
+		}
+	}
+	return result, nil
+}
+
+// fakeEtcdKV is an in-memory etcdKV, letting registry tests exercise a real
+// Register/Discover/Deregister round trip without a live etcd cluster.
+type fakeEtcdKV struct {
+	nextLease clientv3.LeaseID
+	values    map[string]string
+}
+
+func newFakeEtcdKV() *fakeEtcdKV {
+	return &fakeEtcdKV{values: make(map[string]string)}
+}
+
+func (f *fakeEtcdKV) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	f.nextLease++
+	return &clientv3.LeaseGrantResponse{ID: f.nextLease}, nil
+}
+
+func (f *fakeEtcdKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	f.values[key] = val
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fakeEtcdKV) KeepAliveOnce(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("unknown lease")
+	}
+	return &clientv3.LeaseKeepAliveResponse{ID: id}, nil
+}
+
+func (f *fakeEtcdKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	delete(f.values, key)
+	return &clientv3.DeleteResponse{}, nil
+}
+
+func (f *fakeEtcdKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	resp := &clientv3.GetResponse{}
+	for k, v := range f.values {
+		if len(k) >= len(key) && k[:len(key)] == key {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return resp, nil
+}
+
+func TestNewRegistryUnknownBackend(t *testing.T) {
+	_, err := newRegistry(RegistryConfig{Backend: "does-not-exist"})
+	assert.Error(t, err)
+}
+
+func TestNewRegistryConsul(t *testing.T) {
+	reg, err := newRegistry(RegistryConfig{Backend: "consul", Endpoint: "127.0.0.1:8500"})
+	assert.NoError(t, err)
+	_, ok := reg.(*consulRegistry)
+	assert.True(t, ok)
+}
+
+func TestNewRegistryEtcd(t *testing.T) {
+	reg, err := newRegistry(RegistryConfig{Backend: "etcd", Endpoint: "127.0.0.1:2379"})
+	assert.NoError(t, err)
+	_, ok := reg.(*etcdRegistry)
+	assert.True(t, ok)
+}
+
+func TestNewEtcdRegistryDefaultPrefix(t *testing.T) {
+	reg, err := newEtcdRegistry(RegistryConfig{Endpoint: "127.0.0.1:2379"}, defaultRegistryTTL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/skaf0/instances/", reg.prefix)
+}
+
+func TestNewEtcdRegistryPrefixGetsTrailingSlash(t *testing.T) {
+	reg, err := newEtcdRegistry(RegistryConfig{Endpoint: "127.0.0.1:2379", Prefix: "/custom"}, defaultRegistryTTL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/custom/", reg.prefix)
+}
+
+func TestEtcdRegistryHeartbeatWithoutRegisterFails(t *testing.T) {
+	reg, err := newEtcdRegistry(RegistryConfig{Endpoint: "127.0.0.1:2379"}, defaultRegistryTTL)
+	assert.NoError(t, err)
+	assert.Error(t, reg.Heartbeat("unregistered-instance"))
+}
+
+func TestConsulRegistryRegisterDiscoverRoundTrip(t *testing.T) {
+	reg := &consulRegistry{agent: newFakeConsulAgent(), ttl: defaultRegistryTTL}
+
+	assert.NoError(t, reg.Register("skaf0-1", "10.0.0.1:9000", []string{"frontend", "backend"}))
+	assert.NoError(t, reg.Heartbeat("skaf0-1"))
+
+	instances, err := reg.Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, []Instance{{
+		ServiceID: "skaf0-1",
+		Addr:      "10.0.0.1:9000",
+		Artifacts: []string{"frontend", "backend"},
+	}}, instances)
+
+	assert.NoError(t, reg.Deregister("skaf0-1"))
+	instances, err = reg.Discover()
+	assert.NoError(t, err)
+	assert.Empty(t, instances)
+}
+
+func TestConsulRegistryHeartbeatWithoutRegisterFails(t *testing.T) {
+	reg := &consulRegistry{agent: newFakeConsulAgent(), ttl: defaultRegistryTTL}
+	assert.Error(t, reg.Heartbeat("unregistered-instance"))
+}
+
+func TestEtcdRegistryRegisterDiscoverRoundTrip(t *testing.T) {
+	reg := &etcdRegistry{
+		client: newFakeEtcdKV(),
+		prefix: "/skaf0/instances/",
+		ttl:    defaultRegistryTTL,
+		leases: make(map[string]clientv3.LeaseID),
+	}
+
+	assert.NoError(t, reg.Register("skaf0-1", "10.0.0.1:9000", []string{"frontend", "backend"}))
+	assert.NoError(t, reg.Heartbeat("skaf0-1"))
+
+	instances, err := reg.Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, []Instance{{
+		ServiceID: "skaf0-1",
+		Addr:      "10.0.0.1:9000",
+		Artifacts: []string{"frontend", "backend"},
+	}}, instances)
+
+	assert.NoError(t, reg.Deregister("skaf0-1"))
+	instances, err = reg.Discover()
+	assert.NoError(t, err)
+	assert.Empty(t, instances)
+}
+
+func TestEtcdRegistryDiscoverDecodesStoredValue(t *testing.T) {
+	fake := newFakeEtcdKV()
+	value, err := json.Marshal(etcdInstanceValue{Addr: "10.0.0.2:9001", Artifacts: []string{"api"}})
+	assert.NoError(t, err)
+	fake.values["/skaf0/instances/skaf0-2"] = string(value)
+
+	reg := &etcdRegistry{client: fake, prefix: "/skaf0/instances/", ttl: defaultRegistryTTL, leases: make(map[string]clientv3.LeaseID)}
+	instances, err := reg.Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, []Instance{{ServiceID: "skaf0-2", Addr: "10.0.0.2:9001", Artifacts: []string{"api"}}}, instances)
+}
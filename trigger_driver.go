@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultDriverName is the TriggerDriver used by artifacts that don't
+// specify one explicitly.
+const defaultDriverName = "write-trigger"
+
+// defaultDebounce is the coalescing window used by the "inotify-batch"
+// driver unless overridden via SetDebounce.
+const defaultDebounce = 250 * time.Millisecond
+
+// TriggerDriver is the mechanism used to notify Skaffold's watcher that an
+// artifact's dependencies changed, triggering a rebuild. Implementations are
+// registered by name via RegisterTriggerDriver and selected per artifact.
+// cause describes why the rebuild fired (e.g. the changed path, or
+// "manual-rebuild" when none was reported) for drivers that report it
+// downstream, such as webhookTriggerDriver.
+type TriggerDriver interface {
+	Name() string
+	Trigger(ctx context.Context, art *artifact, cause string) error
+}
+
+// WatchNotifier is the narrow capability a TriggerDriver needs from the
+// ArtifactResolver: pushing a synthetic file-change event onto an
+// artifact's registered watch.
+type WatchNotifier interface {
+	NotifyWatch(art *artifact) error
+}
+
+// TriggerDriverFactory constructs a TriggerDriver bound to the given
+// notifier. Registered via RegisterTriggerDriver and looked up by name.
+type TriggerDriverFactory func(notifier WatchNotifier) TriggerDriver
+
+var (
+	triggerDriversMtx sync.Mutex
+	triggerDrivers    = make(map[string]TriggerDriverFactory)
+)
+
+func init() {
+	RegisterTriggerDriver(defaultDriverName, newWriteTriggerDriver)
+	RegisterTriggerDriver("inotify-batch", newBatchedTriggerDriver)
+	RegisterTriggerDriver("webhook", newWebhookTriggerDriver)
+}
+
+// RegisterTriggerDriver makes a trigger driver available by name, mirroring
+// the registration pattern used by database/sql drivers so downstream users
+// can compile in custom drivers via an init func. It panics on a duplicate
+// name.
+func RegisterTriggerDriver(name string, factory TriggerDriverFactory) {
+	triggerDriversMtx.Lock()
+	defer triggerDriversMtx.Unlock()
+
+	if _, exists := triggerDrivers[name]; exists {
+		panic(fmt.Sprintf("skaf0: trigger driver %q already registered", name))
+	}
+	triggerDrivers[name] = factory
+}
+
+func isRegisteredTriggerDriver(name string) bool {
+	triggerDriversMtx.Lock()
+	defer triggerDriversMtx.Unlock()
+
+	_, ok := triggerDrivers[name]
+	return ok
+}
+
+func newTriggerDriver(name string, notifier WatchNotifier) (TriggerDriver, error) {
+	triggerDriversMtx.Lock()
+	factory, ok := triggerDrivers[name]
+	triggerDriversMtx.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown trigger driver: %s", name)
+	}
+	return factory(notifier), nil
+}
+
+// writeTriggerDriver is the original skaf0 mechanism: write a random byte to
+// the artifact's trigger file and push a synthetic event onto its watch.
+type writeTriggerDriver struct {
+	notifier WatchNotifier
+}
+
+func newWriteTriggerDriver(notifier WatchNotifier) TriggerDriver {
+	return &writeTriggerDriver{notifier: notifier}
+}
+
+func (d *writeTriggerDriver) Name() string { return defaultDriverName }
+
+func (d *writeTriggerDriver) Trigger(ctx context.Context, art *artifact, cause string) error {
+	return d.notifier.NotifyWatch(art)
+}
+
+// batchedTriggerDriver coalesces rebuild triggers for the same artifact that
+// arrive within a debounce window into a single underlying trigger,
+// preventing rebuild storms during large checkouts or refactors.
+type batchedTriggerDriver struct {
+	inner TriggerDriver
+
+	mtx      sync.Mutex
+	debounce time.Duration
+	timers   map[string]*time.Timer
+}
+
+func newBatchedTriggerDriver(notifier WatchNotifier) TriggerDriver {
+	return &batchedTriggerDriver{
+		inner:    newWriteTriggerDriver(notifier),
+		debounce: defaultDebounce,
+		timers:   make(map[string]*time.Timer),
+	}
+}
+
+func (d *batchedTriggerDriver) Name() string { return "inotify-batch" }
+
+// SetDebounce overrides the coalescing window. Intended to be called once,
+// from config-loading code, before the driver handles its first trigger.
+func (d *batchedTriggerDriver) SetDebounce(debounce time.Duration) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.debounce = debounce
+}
+
+func (d *batchedTriggerDriver) Trigger(ctx context.Context, art *artifact, cause string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if t, ok := d.timers[art.imageName]; ok {
+		t.Stop()
+	}
+	d.timers[art.imageName] = time.AfterFunc(d.debounce, func() {
+		if err := d.inner.Trigger(context.Background(), art, cause); err != nil {
+			fmt.Fprintf(os.Stderr, "skaf0: inotify-batch: deferred trigger failed for %s: %v\n", art.imageName, err)
+		}
+	})
+	return nil
+}
+
+// webhookWaitTimeout bounds how long the webhook driver waits for a
+// configured endpoint to respond.
+const webhookWaitTimeout = 5 * time.Second
+
+// webhookTriggerDriver performs the normal write-trigger notification and
+// additionally POSTs a JSON payload to a configured URL, letting CI and
+// remote-cache systems react to rebuilds.
+type webhookTriggerDriver struct {
+	inner  TriggerDriver
+	client *http.Client
+
+	mtx sync.RWMutex
+	url string
+}
+
+type webhookPayload struct {
+	Artifact  string    `json:"artifact"`
+	Cause     string    `json:"cause"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func newWebhookTriggerDriver(notifier WatchNotifier) TriggerDriver {
+	return &webhookTriggerDriver{
+		inner:  newWriteTriggerDriver(notifier),
+		client: &http.Client{Timeout: webhookWaitTimeout},
+	}
+}
+
+func (d *webhookTriggerDriver) Name() string { return "webhook" }
+
+// SetURL configures the endpoint POSTed to on every trigger. An empty URL
+// (the default) disables the POST, leaving only the write-trigger behavior.
+func (d *webhookTriggerDriver) SetURL(url string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.url = url
+}
+
+func (d *webhookTriggerDriver) Trigger(ctx context.Context, art *artifact, cause string) error {
+	if err := d.inner.Trigger(ctx, art, cause); err != nil {
+		return err
+	}
+
+	d.mtx.RLock()
+	url := d.url
+	d.mtx.RUnlock()
+
+	if url == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		Artifact:  art.imageName,
+		Cause:     cause,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWatchNotifier records NotifyWatch calls and, for tests that need to
+// observe a deferred driver (e.g. inotify-batch) without a sleep-based race,
+// signals each one on notifyCh.
+type fakeWatchNotifier struct {
+	notifyCh chan string
+
+	mtx      sync.Mutex
+	notified []string
+}
+
+func (f *fakeWatchNotifier) NotifyWatch(art *artifact) error {
+	f.mtx.Lock()
+	f.notified = append(f.notified, art.imageName)
+	f.mtx.Unlock()
+
+	if f.notifyCh != nil {
+		f.notifyCh <- art.imageName
+	}
+	return nil
+}
+
+func (f *fakeWatchNotifier) Notified() []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return slices.Clone(f.notified)
+}
+
+func TestNewTriggerDriverDefault(t *testing.T) {
+	notifier := &fakeWatchNotifier{}
+	driver, err := newTriggerDriver(defaultDriverName, notifier)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultDriverName, driver.Name())
+
+	art := &artifact{imageName: "test-image"}
+	assert.NoError(t, driver.Trigger(context.Background(), art, "test-cause"))
+	assert.Equal(t, []string{"test-image"}, notifier.Notified())
+}
+
+func TestNewTriggerDriverUnknown(t *testing.T) {
+	_, err := newTriggerDriver("does-not-exist", &fakeWatchNotifier{})
+	assert.Error(t, err)
+}
+
+func TestBatchedTriggerDriverCoalesces(t *testing.T) {
+	notifier := &fakeWatchNotifier{notifyCh: make(chan string, 1)}
+	driver := newBatchedTriggerDriver(notifier).(*batchedTriggerDriver)
+	driver.SetDebounce(10 * time.Millisecond)
+
+	art := &artifact{imageName: "test-image"}
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, driver.Trigger(context.Background(), art, "test-cause"))
+	}
+
+	assert.Empty(t, notifier.Notified())
+	select {
+	case <-notifier.notifyCh:
+	case <-time.After(time.Second):
+		t.Fatal("batched trigger did not fire")
+	}
+	assert.Equal(t, []string{"test-image"}, notifier.Notified())
+}
+
+func TestWebhookTriggerDriverPostsOnlyOnceURLIsSet(t *testing.T) {
+	var posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := &fakeWatchNotifier{}
+	driver := newWebhookTriggerDriver(notifier).(*webhookTriggerDriver)
+	art := &artifact{imageName: "test-image"}
+
+	// No URL configured yet: the webhook POST is skipped, but the
+	// underlying write-trigger notification still fires.
+	assert.NoError(t, driver.Trigger(context.Background(), art, "test-cause"))
+	assert.Equal(t, 0, posts)
+	assert.Equal(t, []string{"test-image"}, notifier.Notified())
+
+	driver.SetURL(server.URL)
+	assert.NoError(t, driver.Trigger(context.Background(), art, "test-cause"))
+	assert.Equal(t, 1, posts)
+}
+
+func TestWebhookTriggerDriverPostsRealCause(t *testing.T) {
+	var received webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	driver := newWebhookTriggerDriver(&fakeWatchNotifier{}).(*webhookTriggerDriver)
+	driver.SetURL(server.URL)
+
+	art := &artifact{imageName: "test-image"}
+	assert.NoError(t, driver.Trigger(context.Background(), art, "main.go"))
+	assert.Equal(t, "main.go", received.Cause)
+}
+
+func TestRegisterTriggerDriverDuplicatePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterTriggerDriver(defaultDriverName, newWriteTriggerDriver)
+	})
+}